@@ -0,0 +1,280 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httptrace
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http/httptrace"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/semconv"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// inflightSpan pairs a sub-span with the context Start returned it in, so
+// that only the attempt which actually wins a race (e.g. the connection a
+// dual-stack "Happy Eyeballs" dial settles on) gets to reparent the
+// phases that follow it.
+type inflightSpan struct {
+	ctx  context.Context
+	span trace.Span
+}
+
+// clientTrace holds the root span for the HTTP round trip together with
+// the currently open DNS/TLS sub-spans and the in-flight Connect
+// sub-spans, so that hooks which are invoked in pairs (Start/Done) can
+// close the span the matching Start hook opened. net/http/httptrace
+// calls these hooks from whatever goroutine is dialing at the time --
+// concurrently, under dual-stack "Happy Eyeballs" -- so every field
+// below, including ctx, is guarded by mtx rather than assumed to belong
+// to a single goroutine.
+type clientTrace struct {
+	tr trace.Tracer
+
+	root        trace.Span
+	endRootOnce sync.Once
+
+	mtx        sync.Mutex
+	ctx        context.Context
+	dns        trace.Span
+	tlsHandshk trace.Span
+	// connect is keyed by addr because Go dials multiple addresses
+	// concurrently under dual-stack "Happy Eyeballs": ConnectStart and
+	// ConnectDone pairs for different addresses can be in flight at the
+	// same time, and a single span field would have one overwrite
+	// another's in-progress span.
+	connect map[string]inflightSpan
+}
+
+// context returns the context phase hooks should start their sub-spans
+// from: the request's context until a phase (DNS, Connect, TLS) replaces
+// it with one carrying that phase's span.
+func (ct *clientTrace) context() context.Context {
+	ct.mtx.Lock()
+	defer ct.mtx.Unlock()
+	return ct.ctx
+}
+
+// NewClientTrace returns an httptrace.ClientTrace that records the phases
+// of an outgoing HTTP request as events and sub-spans of a span started
+// on ctx using tracer, and a func that ends the root span. The returned
+// context carries the root span and must be used for the request that the
+// trace is attached to, and the returned func must be called once the
+// round trip is over (e.g. via defer) so the root span is always ended,
+// even if the request fails before any hook that ends it on its own runs:
+//
+//	trace, ctx, end := httptrace.NewClientTrace(ctx, tracer)
+//	defer end()
+//	req = req.WithContext(gohttptrace.WithClientTrace(ctx, trace))
+func NewClientTrace(ctx context.Context, tracer trace.Tracer) (*httptrace.ClientTrace, context.Context, func()) {
+	ctx, root := tracer.Start(ctx, "http.getconn")
+
+	ct := &clientTrace{
+		ctx:     ctx,
+		tr:      tracer,
+		root:    root,
+		connect: make(map[string]inflightSpan),
+	}
+
+	return &httptrace.ClientTrace{
+		GetConn:              ct.getConn,
+		GotConn:              ct.gotConn,
+		PutIdleConn:          ct.putIdleConn,
+		DNSStart:             ct.dnsStart,
+		DNSDone:              ct.dnsDone,
+		ConnectStart:         ct.connectStart,
+		ConnectDone:          ct.connectDone,
+		TLSHandshakeStart:    ct.tlsHandshakeStart,
+		TLSHandshakeDone:     ct.tlsHandshakeDone,
+		WroteHeaders:         ct.wroteHeaders,
+		WroteRequest:         ct.wroteRequest,
+		GotFirstResponseByte: ct.gotFirstResponseByte,
+	}, ctx, ct.endRoot
+}
+
+// endRoot ends the root span. It's safe to call more than once: the hooks
+// that can observe the round trip succeeding or failing both end the root
+// span themselves so it's exported as soon as possible, and the caller's
+// deferred call is then just a backstop for paths (context cancellation,
+// a transport error before any hook fires) that no ClientTrace hook
+// observes.
+func (ct *clientTrace) endRoot() {
+	ct.endRootOnce.Do(ct.root.End)
+}
+
+func (ct *clientTrace) getConn(hostPort string) {
+	ct.root.AddEvent("http.getconn", trace.WithAttributes(attribute.String("http.remote", hostPort)))
+}
+
+func (ct *clientTrace) gotConn(info httptrace.GotConnInfo) {
+	ct.root.SetAttributes(
+		attribute.Bool("http.conn.reused", info.Reused),
+		attribute.Bool("http.conn.was_idle", info.WasIdle),
+	)
+	if info.WasIdle {
+		ct.root.SetAttributes(attribute.String("http.conn.idle_time", info.IdleTime.String()))
+	}
+	ct.root.AddEvent("http.getconn.done")
+}
+
+func (ct *clientTrace) putIdleConn(err error) {
+	if err != nil {
+		ct.root.RecordError(err)
+		return
+	}
+	ct.root.AddEvent("http.putidleconn")
+}
+
+func (ct *clientTrace) dnsStart(info httptrace.DNSStartInfo) {
+	ctx, span := ct.tr.Start(ct.context(), "http.dns", trace.WithAttributes(
+		semconv.NetPeerNameKey.String(info.Host),
+	))
+	ct.mtx.Lock()
+	ct.dns = span
+	ct.ctx = ctx
+	ct.mtx.Unlock()
+}
+
+func (ct *clientTrace) dnsDone(info httptrace.DNSDoneInfo) {
+	ct.mtx.Lock()
+	span := ct.dns
+	ct.mtx.Unlock()
+	if span == nil {
+		return
+	}
+	if info.Err != nil {
+		span.RecordError(info.Err)
+	}
+	addrs := make([]string, 0, len(info.Addrs))
+	for _, addr := range info.Addrs {
+		addrs = append(addrs, addr.String())
+	}
+	span.SetAttributes(attribute.String("net.peer.addresses", strings.Join(addrs, ",")))
+	span.End()
+}
+
+func (ct *clientTrace) connectStart(network, addr string) {
+	host, port := splitHostPort(addr)
+	ctx, span := ct.tr.Start(ct.context(), "http.connect", trace.WithAttributes(
+		semconv.NetPeerNameKey.String(host),
+		semconv.NetPeerPortKey.Int(port),
+		attribute.String("net.transport", network),
+	))
+	ct.mtx.Lock()
+	ct.connect[addr] = inflightSpan{ctx: ctx, span: span}
+	ct.mtx.Unlock()
+}
+
+func (ct *clientTrace) connectDone(network, addr string, err error) {
+	ct.mtx.Lock()
+	in, ok := ct.connect[addr]
+	delete(ct.connect, addr)
+	ct.mtx.Unlock()
+	if !ok {
+		return
+	}
+	if err != nil {
+		in.span.RecordError(err)
+		in.span.End()
+		return
+	}
+	in.span.End()
+	// This attempt is the one Happy Eyeballs settled on: later phases
+	// (TLS handshake, wrote headers, ...) nest under it. A losing
+	// attempt's context is simply discarded above.
+	ct.mtx.Lock()
+	ct.ctx = in.ctx
+	ct.mtx.Unlock()
+}
+
+func (ct *clientTrace) tlsHandshakeStart() {
+	ctx, span := ct.tr.Start(ct.context(), "http.tls")
+	ct.mtx.Lock()
+	ct.tlsHandshk = span
+	ct.ctx = ctx
+	ct.mtx.Unlock()
+}
+
+func (ct *clientTrace) tlsHandshakeDone(state tls.ConnectionState, err error) {
+	ct.mtx.Lock()
+	span := ct.tlsHandshk
+	ct.mtx.Unlock()
+	if span == nil {
+		return
+	}
+	if err != nil {
+		span.RecordError(err)
+	} else {
+		span.SetAttributes(
+			attribute.String("tls.protocol.version", tlsVersionString(state.Version)),
+			attribute.String("net.peer.name", state.ServerName),
+		)
+	}
+	span.End()
+}
+
+func (ct *clientTrace) wroteHeaders() {
+	ct.root.AddEvent("http.wroteheaders")
+}
+
+func (ct *clientTrace) wroteRequest(info httptrace.WroteRequestInfo) {
+	if info.Err != nil {
+		ct.root.RecordError(info.Err)
+		// The request was never fully sent, so no response is coming:
+		// this is as much of the round trip as we'll ever see.
+		ct.endRoot()
+		return
+	}
+	ct.root.AddEvent("http.wroterequest")
+}
+
+func (ct *clientTrace) gotFirstResponseByte() {
+	ct.root.AddEvent("http.receivedfirstbyte")
+	ct.endRoot()
+}
+
+func splitHostPort(hostPort string) (host string, port int) {
+	h, p, err := net.SplitHostPort(hostPort)
+	if err != nil {
+		return hostPort, 0
+	}
+	port, err = strconv.Atoi(p)
+	if err != nil {
+		return h, 0
+	}
+	return h, port
+}
+
+func tlsVersionString(version uint16) string {
+	switch version {
+	case tls.VersionTLS10:
+		return "1.0"
+	case tls.VersionTLS11:
+		return "1.1"
+	case tls.VersionTLS12:
+		return "1.2"
+	case tls.VersionTLS13:
+		return "1.3"
+	default:
+		return "unknown"
+	}
+}
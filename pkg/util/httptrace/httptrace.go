@@ -25,21 +25,32 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"k8s.io/klog/v2"
 
-	apitrace "go.opentelemetry.io/otel/api/trace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 type contextKeyType int
 
-const spanContextAnnotationKey string = "trace.kubernetes.io/context"
+// These annotation keys match k8s.io/kubernetes/pkg/util/trace's
+// TraceAnnotationKey/TraceStateAnnotationKey: both packages read and write
+// the same annotations, so a trace started on one code path can be
+// resumed on the other.
+const (
+	spanContextAnnotationKey string = "trace.kubernetes.io/context"
+	traceStateAnnotationKey  string = "trace.kubernetes.io/state"
+)
+
+const traceParentVersion = "00"
 
-func stringToSpanContext(sc string) apitrace.SpanContext {
-	id, _ := apitrace.IDFromHex(sc[0:32])
-	spanid, _ := apitrace.SpanIDFromHex(sc[33:49])
-	return apitrace.SpanContext{
+func stringToSpanContext(sc string) trace.SpanContext {
+	id, _ := trace.TraceIDFromHex(sc[0:32])
+	spanid, _ := trace.SpanIDFromHex(sc[33:49])
+	return trace.SpanContext{
 		TraceID: id,
 		SpanID:  spanid,
 	}
@@ -74,9 +85,12 @@ func IsStatusOnly(field metav1.ManagedFieldsEntry) bool {
 	}
 
 	c := make(map[string]json.RawMessage)
-	e := json.Unmarshal(field.FieldsV1.Raw, &c)
-	if e != nil {
-		panic(e)
+	if err := json.Unmarshal(field.FieldsV1.Raw, &c); err != nil {
+		// A malformed FieldsV1 must never crash the apiserver request
+		// path; treat it as not status-only so WithObject still
+		// considers it rather than silently dropping a candidate.
+		klog.ErrorS(err, "failed to unmarshal managed fields", "manager", field.Manager)
+		return false
 	}
 
 	for s, _ := range c {
@@ -90,61 +104,56 @@ func IsStatusOnly(field metav1.ManagedFieldsEntry) bool {
 	return statusOnly
 }
 
-// WithObject returns a context attached with a Span retrieved from object annotation, it doesn't start a new span
+// WithObject returns a context attached with a Span retrieved from the
+// object's managed fields, it doesn't start a new span.
+//
+// Among the managed fields entries whose generation is newer than obv
+// (the generation the caller last observed), it picks the one with the
+// earliest Time: the write that first made the object diverge from what
+// the caller saw. If none are newer, it falls back to the entry with the
+// latest Time among those at exactly obv. If neither exists, ctx is
+// returned unchanged rather than starting a trace from a fabricated span.
 func WithObject(ctx context.Context, meta metav1.Object, obv int64) context.Context {
-	var latestContext string
-	// var latestTimeStamp *metav1.Time
-	var gen int64
-	var acontext []string
-	var bcontext []string
-
-	managedFields := meta.GetManagedFields()
-	for _, mf := range managedFields {
-		if IsStatusOnly(mf) {
+	var (
+		candidate     string
+		candidateTime time.Time
+		haveCandidate bool
+		haveNewer     bool
+	)
+
+	for _, mf := range meta.GetManagedFields() {
+		if IsStatusOnly(mf) || mf.TraceContext == "" || mf.Time == nil {
 			continue
 		}
 
 		s := strings.Split(mf.TraceContext, "-")
-		gen, _ = strconv.ParseInt(s[len(s)-1], 10, 64)
-		if gen > obv {
-			acontext = append(acontext, mf.TraceContext)
-			klog.V(3).InfoS("AAA: Trace request", "object", klog.KObj(meta), "ObG", obv, "Generation", meta.GetGeneration(), "trace-id", mf.TraceContext)
-		} else if gen == obv {
-			bcontext = append(bcontext, mf.TraceContext)
-			klog.V(3).InfoS("BBB: Trace request", "object", klog.KObj(meta), "ObG", obv, "Generation", meta.GetGeneration(), "trace-id", mf.TraceContext)
-		} else {
+		gen, err := strconv.ParseInt(s[len(s)-1], 10, 64)
+		if err != nil || gen < obv {
 			continue
 		}
-		/*
-			if latestTimeStamp != nil {
-				if latestTimeStamp.Before(mf.Time) {
-					latestTimeStamp = mf.Time
-					latestContext = mf.TraceContext
-				}
-			} else {
-				latestTimeStamp = mf.Time
-				latestContext = mf.TraceContext
-			}
-		*/
 
-		//klog.V(3).InfoS("Trace request", "object", klog.KObj(meta), "ObG", obv, "Generation", meta.GetGeneration(), "trace-id", mf.TraceContext)
+		t := mf.Time.Time
+		switch {
+		case gen > obv:
+			if !haveNewer || t.Before(candidateTime) {
+				candidate, candidateTime, haveCandidate, haveNewer = mf.TraceContext, t, true, true
+			}
+		case haveNewer:
+			// A newer-generation entry always wins over one at
+			// exactly obv.
+		default:
+			if !haveCandidate || t.After(candidateTime) {
+				candidate, candidateTime, haveCandidate = mf.TraceContext, t, true
+			}
+		}
 	}
 
-	if len(acontext) > 0 {
-		latestContext = acontext[0]
-	} else if len(bcontext) > 0 {
-		latestContext = bcontext[0]
-	} else {
-		latestContext = "6617856f277e317fa7aab4c66e0041c9-2aa8325022d99d40-0"
-		klog.V(3).InfoS("CCC: Trace request", "object", klog.KObj(meta), "ObG", obv, "Generation", meta.GetGeneration(), "trace-id", latestContext)
+	if !haveCandidate {
+		return ctx
 	}
 
-	span := httpTraceSpan{
-		spanContext: stringToSpanContext(latestContext),
-	}
-	//klog.V(3).InfoS("Trace request", "object", klog.KObj(meta), "trace-id", latestContext)
-	return apitrace.ContextWithSpan(ctx, span)
-	// return spanContextFromAnnotations(ctx, meta, meta.GetAnnotations())
+	klog.V(3).InfoS("Trace request", "object", klog.KObj(meta), "observedGeneration", obv, "generation", meta.GetGeneration(), "trace-id", candidate)
+	return trace.ContextWithRemoteSpanContext(ctx, stringToSpanContext(candidate))
 }
 
 // spanContextFromAnnotations get span context from annotations
@@ -154,14 +163,19 @@ func spanContextFromAnnotations(ctx context.Context, meta metav1.Object, annotat
 	if err != nil {
 		return ctx
 	}
-	span := httpTraceSpan{
-		spanContext: spanContext,
-	}
 	klog.V(3).InfoS("Trace request", "object", klog.KObj(meta), "trace-id", spanContextString(spanContext))
-	return apitrace.ContextWithSpan(ctx, span)
+	return trace.ContextWithRemoteSpanContext(ctx, spanContext)
 }
 
-func spanContextString(spanContext apitrace.SpanContext) string {
+// InjectIntoObject writes the span active in ctx into meta's annotations
+// as a W3C traceparent, so a later WithObject/spanContextFromAnnotations
+// call - possibly from a different writer - can resume the same trace.
+// The span's tracestate is not written.
+func InjectIntoObject(ctx context.Context, meta metav1.Object) {
+	objectPropagator{}.Inject(ctx, objectAnnotationCarrier{meta: meta})
+}
+
+func spanContextString(spanContext trace.SpanContext) string {
 	return fmt.Sprintf("%s-%s-%02d", spanContext.TraceID, spanContext.SpanID, spanContext.TraceFlags)
 }
 
@@ -173,21 +187,141 @@ func StringSpanContextFromObject(meta metav1.Object) string {
 	return spanContextString(spanContext)
 }
 
-// decodeSpanContext decode encodedSpanContext to spanContext
-func decodeSpanContext(encodedSpanContext string) (apitrace.SpanContext, error) {
+// decodeSpanContext parses encodedSpanContext as a W3C traceparent
+// string, the format InjectIntoObject and pkg/util/trace now both write.
+// Objects annotated before this change instead carry a base64-encoded
+// binary SpanContext, so decodeLegacySpanContext is tried as a fallback
+// rather than treating them as untraced.
+func decodeSpanContext(encodedSpanContext string) (trace.SpanContext, error) {
+	if spanContext, err := decodeTraceParent(encodedSpanContext); err == nil {
+		return spanContext, nil
+	}
+	return decodeLegacySpanContext(encodedSpanContext)
+}
+
+// legacyBinarySpanContext is the fixed-size on-the-wire layout of the
+// base64-encoded binary SpanContext this package wrote before it adopted
+// W3C traceparent strings. It's kept separate from trace.SpanContext,
+// whose own memory layout isn't part of its API contract and has grown
+// fields (TraceState, remote) since this format was written.
+type legacyBinarySpanContext struct {
+	TraceID    [16]byte
+	SpanID     [8]byte
+	TraceFlags byte
+}
+
+// decodeLegacySpanContext decodes the base64-encoded binary SpanContext
+// format this package wrote before it adopted W3C traceparent strings.
+func decodeLegacySpanContext(encodedSpanContext string) (trace.SpanContext, error) {
 	// decode to byte
 	byteList := make([]byte, base64.StdEncoding.DecodedLen(len(encodedSpanContext)))
 	l, err := base64.StdEncoding.Decode(byteList, []byte(encodedSpanContext))
 	if err != nil {
-		return apitrace.EmptySpanContext(), err
+		return trace.SpanContext{}, err
 	}
 	byteList = byteList[:l]
 	// decode to span context
 	buffer := bytes.NewBuffer(byteList)
-	spanContext := apitrace.SpanContext{}
-	err = binary.Read(buffer, binary.LittleEndian, &spanContext)
+	legacy := legacyBinarySpanContext{}
+	if err := binary.Read(buffer, binary.LittleEndian, &legacy); err != nil {
+		return trace.SpanContext{}, err
+	}
+	return trace.SpanContext{
+		TraceID:    legacy.TraceID,
+		SpanID:     legacy.SpanID,
+		TraceFlags: legacy.TraceFlags,
+	}, nil
+}
+
+// encodeTraceParent formats sc as a W3C traceparent header value.
+func encodeTraceParent(sc trace.SpanContext) string {
+	return fmt.Sprintf("%s-%s-%s-%02x", traceParentVersion, sc.TraceID, sc.SpanID, sc.TraceFlags)
+}
+
+// decodeTraceParent parses a W3C traceparent header value of the form
+// "version-traceid-spanid-flags".
+func decodeTraceParent(traceparent string) (trace.SpanContext, error) {
+	parts := strings.Split(traceparent, "-")
+	if len(parts) != 4 || len(parts[1]) != 32 || len(parts[2]) != 16 {
+		return trace.SpanContext{}, fmt.Errorf("invalid traceparent %q", traceparent)
+	}
+	traceID, err := trace.TraceIDFromHex(parts[1])
+	if err != nil {
+		return trace.SpanContext{}, err
+	}
+	spanID, err := trace.SpanIDFromHex(parts[2])
 	if err != nil {
-		return apitrace.EmptySpanContext(), err
+		return trace.SpanContext{}, err
 	}
-	return spanContext, nil
+	flags, err := strconv.ParseUint(parts[3], 16, 8)
+	if err != nil {
+		return trace.SpanContext{}, err
+	}
+	return trace.SpanContext{TraceID: traceID, SpanID: spanID, TraceFlags: byte(flags)}, nil
+}
+
+// objectAnnotationCarrier adapts an object's annotations to
+// propagation.TextMapCarrier, mapping the traceparent/tracestate keys
+// onto this package's annotation keys.
+type objectAnnotationCarrier struct {
+	meta metav1.Object
+}
+
+func (c objectAnnotationCarrier) Get(key string) string {
+	switch key {
+	case "traceparent":
+		return c.meta.GetAnnotations()[spanContextAnnotationKey]
+	case "tracestate":
+		return c.meta.GetAnnotations()[traceStateAnnotationKey]
+	}
+	return ""
+}
+
+func (c objectAnnotationCarrier) Set(key, value string) {
+	annotations := c.meta.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	switch key {
+	case "traceparent":
+		annotations[spanContextAnnotationKey] = value
+	case "tracestate":
+		annotations[traceStateAnnotationKey] = value
+	default:
+		return
+	}
+	c.meta.SetAnnotations(annotations)
+}
+
+func (c objectAnnotationCarrier) Keys() []string {
+	return []string{"traceparent", "tracestate"}
+}
+
+// objectPropagator implements propagation.TextMapPropagator like the
+// standard propagation.TraceContext{}, but without it: Extract must also
+// accept the legacy base64 binary SpanContext format this package wrote
+// before annotations carried W3C traceparent strings, which
+// propagation.TraceContext{} knows nothing about.
+type objectPropagator struct{}
+
+var _ propagation.TextMapPropagator = objectPropagator{}
+
+func (objectPropagator) Inject(ctx context.Context, carrier propagation.TextMapCarrier) {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.IsValid() {
+		return
+	}
+	carrier.Set("traceparent", encodeTraceParent(spanContext))
+}
+
+func (objectPropagator) Extract(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	spanContext, err := decodeSpanContext(carrier.Get("traceparent"))
+	if err != nil {
+		return ctx
+	}
+	return trace.ContextWithRemoteSpanContext(ctx, spanContext)
+}
+
+func (objectPropagator) Fields() []string {
+	return []string{"traceparent"}
 }
@@ -1,210 +1,140 @@
-// // Package traceutil provides various definitions and utilities that allow for
-// // common operations with our trace tooling, such as span creation, encoding, decoding,
-// // and enumeration of possible services.
-// package traceutil
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package traceutil provides various definitions and utilities that allow for
+// common operations with our trace tooling, such as span creation, encoding,
+// decoding, and propagation of trace context through Kubernetes objects.
 package traceutil
 
 import (
 	"context"
-	"encoding/base64"
-	"log"
 
-	"contrib.go.opencensus.io/exporter/ocagent"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 
-	"go.opencensus.io/trace"
-	"go.opencensus.io/trace/propagation"
-	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/klog"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
 )
 
-// TraceAnnotationKey is the annotation name where span context should be found
-const TraceAnnotationKey string = "trace.kubernetes.io/context"
-
-// InitializeExporter takes a ServiceType and sets the global OpenCensus exporter
-// to export to that service on a specified Zipkin instance
-func InitializeExporter(service string) {
-	klog.Infof("OpenCensus trace exporter initializing with service %s", string(service))
+// TraceAnnotationKey is the annotation holding the W3C traceparent header
+// value for the span that last touched an object. TraceStateAnnotationKey
+// holds the accompanying tracestate header, if any.
+const (
+	TraceAnnotationKey      string = "trace.kubernetes.io/context"
+	TraceStateAnnotationKey string = "trace.kubernetes.io/state"
+)
 
-	// create ocagent exporter
-	exp, err := ocagent.NewExporter(ocagent.WithInsecure(), ocagent.WithServiceName(string(service)))
-	if err != nil {
-		log.Fatalf("Failed to create the agent exporter: %v", err)
-	}
-	// Only sample when the propagated parent SpanContext is sampled
-	// Use ProbabilitySampler because it propagates the parent sampling decision.
-    trace.ApplyConfig(trace.Config{DefaultSampler: trace.ProbabilitySampler(0)})
+var propagator = propagation.TraceContext{}
 
-	trace.RegisterExporter(exp)
+const tracerName = "k8s.io/kubernetes/pkg/util/trace"
 
-	return
+// objectCarrier adapts an object's annotations to propagation.TextMapCarrier
+// so a standard W3C TraceContext propagator can read/write them, storing
+// the "traceparent"/"tracestate" headers under our own annotation keys
+// rather than the bare header names.
+type objectCarrier struct {
+	meta metav1.Object
 }
 
-// StartSpanFromObject takes an object to extract trace context from and the desired Span name and
-// constructs a new Span from this information.  It mirrors trace.StartSpan, but for kubernetes objects.
-func StartSpanFromObject(ctx context.Context, tracedResource meta.Object, name string) (context.Context, *trace.Span) {
-	klog.Infof("OC trace:StartSpanFromObject %s", string(name))
-	spanFromEncodedContext, ok := spanContextFromObject(tracedResource)
-	if !ok {
-		return ctx, &trace.Span{}
+func (c objectCarrier) Get(key string) string {
+	switch key {
+	case "traceparent":
+		return c.meta.GetAnnotations()[TraceAnnotationKey]
+	case "tracestate":
+		return c.meta.GetAnnotations()[TraceStateAnnotationKey]
+	default:
+		return ""
 	}
-	klog.Infof("OC trace:StartSpanFromObject TraceID : %s", spanFromEncodedContext.TraceID)
-	return trace.StartSpanWithRemoteParent(ctx, name, spanFromEncodedContext)
 }
 
-// spanContextFromObject takes an object to extract an encoded SpanContext from and returns the decoded SpanContext
-func spanContextFromObject(tracedResource meta.Object) (trace.SpanContext, bool) {
-	tracedResourceAnnotations := tracedResource.GetAnnotations()
-	embeddedSpanContext, ok := tracedResourceAnnotations[TraceAnnotationKey]
-	if !ok {
-		return trace.SpanContext{}, false
+func (c objectCarrier) Set(key, value string) {
+	annotationKey := ""
+	switch key {
+	case "traceparent":
+		annotationKey = TraceAnnotationKey
+	case "tracestate":
+		annotationKey = TraceStateAnnotationKey
+	default:
+		return
 	}
 
-	decodedContextBytes, err := base64.StdEncoding.DecodeString(embeddedSpanContext)
-	if err != nil {
-		return trace.SpanContext{}, false
+	annotations := c.meta.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
 	}
+	annotations[annotationKey] = value
+	c.meta.SetAnnotations(annotations)
+}
 
-	return propagation.FromBinary(decodedContextBytes)
+func (c objectCarrier) Keys() []string {
+	return []string{"traceparent", "tracestate"}
+}
 
+// StartSpanFromObject extracts the span context encoded on tracedResource
+// (if any) and starts a new child span named name from it, using the
+// globally configured OpenTelemetry TracerProvider.
+func StartSpanFromObject(ctx context.Context, tracedResource metav1.Object, name string) (context.Context, trace.Span) {
+	ctx = propagator.Extract(ctx, objectCarrier{meta: tracedResource})
+	tracer := otel.GetTracerProvider().Tracer(tracerName)
+	ctx, span := tracer.Start(ctx, name)
+	klog.V(3).InfoS("trace: StartSpanFromObject", "object", klog.KObj(tracedResource), "name", name, "traceID", span.SpanContext().TraceID())
+	return ctx, span
 }
 
-// EncodeContextIntoObject encodes the SpanContext contained in the context into the provided object
-func EncodeContextIntoObject(ctx context.Context, tracedResource meta.Object) {
-	klog.Infof("OC trace:EncodeContextIntoObject") 
-	span := trace.FromContext(ctx)
-	if span != nil {
-		encodeSpanContextIntoObject(span.SpanContext(), tracedResource)
-		klog.Infof("OC trace:EncodeContextIntoObject : TraceID:%s",span.SpanContext().TraceID)
-		tracedResourceAnnotations := tracedResource.GetAnnotations()
-		klog.Infof("OC trace:EncodeContextIntoObject : Annotation?: %s", tracedResourceAnnotations[TraceAnnotationKey])
+// EncodeContextIntoObject encodes the span in ctx, if any, into
+// tracedResource's annotations using the W3C traceparent/tracestate
+// format, so that a later reconciler extracting it with
+// StartSpanFromObject continues the same trace.
+func EncodeContextIntoObject(ctx context.Context, tracedResource metav1.Object) {
+	span := trace.SpanFromContext(ctx)
+	if !span.SpanContext().IsValid() {
+		return
 	}
+	propagator.Inject(ctx, objectCarrier{meta: tracedResource})
+	klog.V(3).InfoS("trace: EncodeContextIntoObject", "object", klog.KObj(tracedResource), "traceID", span.SpanContext().TraceID())
 }
 
-func RemoveSpanContextFromObject(tracedResource meta.Object) {
-	klog.Infof("OC trace:RemoveSpanContextFromObject") 
-
-	tracedResourceAnnotations := tracedResource.GetAnnotations()
-	klog.Infof("OC trace:RemoveSpanContextFromObject : Annotation?: %s", tracedResourceAnnotations[TraceAnnotationKey]) 
-	delete(tracedResourceAnnotations, TraceAnnotationKey)
-	tracedResource.SetAnnotations(tracedResourceAnnotations)
+// RemoveSpanContextFromObject removes any encoded span context from
+// tracedResource's annotations.
+func RemoveSpanContextFromObject(tracedResource metav1.Object) {
+	annotations := tracedResource.GetAnnotations()
+	if annotations == nil {
+		return
+	}
+	delete(annotations, TraceAnnotationKey)
+	delete(annotations, TraceStateAnnotationKey)
+	tracedResource.SetAnnotations(annotations)
 }
 
-// encodeSpanContextIntoObject takes a pointer to an object and a Span Context to embed
-// Base64 encodes the wire format for the SpanContext, and puts it in the object's TraceContext field
-func encodeSpanContextIntoObject(ctx trace.SpanContext, tracedResource meta.Object) {
-	tracedResourceAnnotations := tracedResource.GetAnnotations()
-
-	rawContextBytes := propagation.Binary(ctx)
-	encodedContext := base64.StdEncoding.EncodeToString(rawContextBytes)
-
-	tracedResourceAnnotations[TraceAnnotationKey] = encodedContext
-	tracedResource.SetAnnotations(tracedResourceAnnotations)
-
-	return
+// ReencodeOnMutate returns a client-go cache.TransformFunc-compatible
+// decorator (func(interface{}) (interface{}, error)) that re-encodes the
+// span active on ctx into any object passed through it, using
+// meta.Accessor so it works across object kinds without needing a
+// RESTMapper. Controllers wire this into their Update/Patch path so that
+// a trace started by, say, `kubectl create` keeps flowing through every
+// controller that subsequently reconciles the object.
+func ReencodeOnMutate(ctx context.Context) func(obj interface{}) (interface{}, error) {
+	return func(obj interface{}) (interface{}, error) {
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			return obj, nil
+		}
+		EncodeContextIntoObject(ctx, accessor)
+		return obj, nil
+	}
 }
-
-
-
-
-// import (
-// 	"context"
-// 	"encoding/base64"
-// 	"log"
-
-// 	// "contrib.go.opencensus.io/exporter/ocagent"
-
-// 	// "go.opencensus.io/trace"
-// 	// "go.opencensus.io/trace/propagation"
-	
-// 	"k8s.io/klog"
-// 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
-	
-// 	"go.opentelemetry.io/otel/api/core"
-// 	"go.opentelemetry.io/otel/api/global"
-// 	"go.opentelemetry.io/otel/api/propagators"
-// 	"go.opentelemetry.io/otel/api/trace"
-
-// 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
-
-// 	"go.opentelemetry.io/otel/exporter/trace/stdout"
-// )
-
-
-// // TraceAnnotationKey is the annotation name where span context should be found
-// const TraceAnnotationKey string = "trace.kubernetes.io/context"
-
-// // InitializeExporter takes a ServiceType and sets the global OpenCensus exporter
-// // to export to that service on a specified Zipkin instance
-// func InitializeExporter(service string) {
-// 	klog.Infof("OpenCensus trace exporter initializing with service %s", string(service))
-
-// 	exporter, err := stdout.NewExporter(stdout.Options{PrettyPrint: true})
-// 	if err != nil {
-// 		log.Fatal(err)
-// 	}
-// 	tp, err := sdktrace.NewProvider(sdktrace.WithConfig(sdktrace.Config{DefaultSampler: sdktrace.AlwaysSample()}),
-// 		sdktrace.WithSyncer(exporter))
-// 	if err != nil {
-// 		log.Fatal(err)
-// 	}
-// 	global.SetTraceProvider(tp)
-// }
-
-// // StartSpanFromObject takes an object to extract trace context from and the desired Span name and
-// // constructs a new Span from this information.  It mirrors trace.StartSpan, but for kubernetes objects.
-// func StartSpanFromObject(ctx context.Context, tracedResource meta.Object, name string) (context.Context, *sdktrace.Span) {
-// 	spanFromEncodedContext, ok := spanContextFromObject(tracedResource)
-// 	if !ok {
-// 		return ctx, &sdktrace.Span{}
-// 	}
-// 	tr := global.TraceProvider().Tracer("trace/traceutil")
-// 	//return trace.StartSpanWithRemoteParent(ctx, name, spanFromEncodedContext)
-// 	return tr.Start(
-// 		trace.ContextWithRemoteSpanContext(ctx, spanFromEncodedContext),name)
-// }
-
-// // spanContextFromObject takes an object to extract an encoded SpanContext from and returns the decoded SpanContext
-// func spanContextFromObject(tracedResource meta.Object) (core.SpanContext, bool) {
-// 	tracedResourceAnnotations := tracedResource.GetAnnotations()
-// 	embeddedSpanContext, ok := tracedResourceAnnotations[TraceAnnotationKey]
-// 	if !ok {
-// 		return core.SpanContext{}, false
-// 	}
-
-// 	decodedContextBytes, err := base64.StdEncoding.DecodeString(embeddedSpanContext)
-// 	if err != nil {
-// 		return core.SpanContext{}, false
-// 	}
-
-// 	//return propagation.FromBinary(decodedContextBytes)
-// 	return propagators.FromBytes(decodedContextBytes)
-
-// }
-
-// // EncodeContextIntoObject encodes the SpanContext contained in the context into the provided object
-// func EncodeContextIntoObject(ctx context.Context, tracedResource meta.Object) {
-// 	span := sdktrace.FromContext(ctx)
-// 	if span != nil {
-// 		encodeSpanContextIntoObject(span.SpanContext(), tracedResource)
-// 	}
-// }
-
-// func RemoveSpanContextFromObject(tracedResource meta.Object) {
-// 	tracedResourceAnnotations := tracedResource.GetAnnotations()
-// 	delete(tracedResourceAnnotations, TraceAnnotationKey)
-// 	tracedResource.SetAnnotations(tracedResourceAnnotations)
-// }
-
-// // encodeSpanContextIntoObject takes a pointer to an object and a Span Context to embed
-// // Base64 encodes the wire format for the SpanContext, and puts it in the object's TraceContext field
-// func encodeSpanContextIntoObject(ctx core.SpanContext, tracedResource meta.Object) {
-// 	tracedResourceAnnotations := tracedResource.GetAnnotations()
-
-// 	rawContextBytes := propagators.Binary(ctx)
-// 	encodedContext := base64.StdEncoding.EncodeToString(rawContextBytes)
-
-// 	tracedResourceAnnotations[TraceAnnotationKey] = encodedContext
-// 	tracedResource.SetAnnotations(tracedResourceAnnotations)
-
-// 	return
-// }
\ No newline at end of file
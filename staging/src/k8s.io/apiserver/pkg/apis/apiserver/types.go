@@ -0,0 +1,158 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+package apiserver
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// OpenTelemetryClientConfiguration provides versioned configuration for opentelemetry clients.
+type OpenTelemetryClientConfiguration struct {
+	metav1.TypeMeta
+
+	// URL is the endpoint to send spans to, in host:port form, with no
+	// scheme. Service and URL are mutually exclusive; if neither is set,
+	// a default URL is used.
+	URL *string
+
+	// Service locates the collector via a Kubernetes Service rather
+	// than a fixed URL. Service and URL are mutually exclusive.
+	Service *ServiceReference
+
+	// Exporter configures how spans are shipped out of the component.
+	// If unset, InitTraces picks its own default exporter.
+	Exporter *ExporterConfig
+
+	// Sampling configures which traces this component starts on its
+	// own, rather than only continuing ones whose parent was already
+	// sampled.
+	Sampling *SamplingPolicy
+
+	// TLS configures the transport security used to reach the
+	// collector. It only applies to the otlp exporter's gRPC
+	// connection; it is ignored by exporters that don't dial out over
+	// gRPC.
+	TLS *TLSConfig
+}
+
+// ServiceReference holds a reference to a Kubernetes Service that can be used to locate
+// the open telemetry collector
+type ServiceReference struct {
+	// Namespace is the namespace of the service
+	Namespace string
+
+	// Name is the name of the service
+	Name string
+
+	// Port is the port on the service that's hosting the open telemetry collector. Defaults to 55680.
+	// +optional
+	Port *int32
+}
+
+// ExporterConfig selects and configures the exporter that ships spans out
+// of the component.
+type ExporterConfig struct {
+	// Type names the exporter to use, e.g. "otlp", "jaeger", "zipkin" or
+	// "stdout". Defaults to whatever InitTraces' caller chooses.
+	Type string
+
+	// Endpoint is the collector address the exporter dials or posts
+	// to. Its syntax (host:port, or a full URL) depends on Type.
+	Endpoint string
+
+	// Headers are additional headers sent with every exported batch,
+	// e.g. for collector authentication. Only the otlp exporter sends
+	// them; the other exporters ignore them.
+	Headers map[string]string
+
+	// Insecure disables transport security for the exporter's
+	// connection. It is mutually exclusive with TLS.
+	Insecure bool
+
+	// Protocol selects the wire protocol the otlp exporter dials with,
+	// e.g. "grpc" or "http/protobuf". It is only meaningful when Type
+	// is "otlp".
+	Protocol string
+}
+
+// SamplingPolicy configures which traces a component starts on its own.
+type SamplingPolicy struct {
+	// Type selects the fallback sampling strategy applied when none of
+	// Rules match, e.g. "Always", "Never", "ParentBased" or
+	// "TraceIDRatio".
+	Type string
+
+	// TraceRatio is the fraction of traces to start, between 0 and 1,
+	// used when no rule matches and Type is "TraceIDRatio" (the default)
+	// or "ParentBased". Rules use their own Ratio instead.
+	TraceRatio float64
+
+	// Rules are evaluated in order before falling back to Type; the
+	// first matching rule decides whether and how often to sample.
+	Rules []SamplingRule
+}
+
+// SamplingRule matches requests by verb, resource, namespace and/or user
+// agent, and decides how the matching requests are sampled.
+type SamplingRule struct {
+	// Verb, if non-empty, restricts this rule to requests with this verb.
+	Verb string
+
+	// Resource, if non-empty, restricts this rule to requests against this resource.
+	Resource string
+
+	// Namespace, if non-empty, restricts this rule to requests in this namespace.
+	Namespace string
+
+	// UserAgent, if non-empty, restricts this rule to requests whose
+	// User-Agent contains this string.
+	UserAgent string
+
+	// Ratio is the fraction of matching requests to sample, between 0 and 1.
+	Ratio float64
+
+	// Drop, if true, means matching requests are never sampled,
+	// regardless of Ratio.
+	Drop bool
+}
+
+// TLSConfig configures the transport security used to reach the collector.
+type TLSConfig struct {
+	// CAFile is the path to a PEM-encoded CA bundle used to verify the
+	// collector's certificate. It is watched and reloaded, so its
+	// contents can be rotated without restarting the component.
+	CAFile string
+
+	// CertFile is the path to a PEM-encoded client certificate, for
+	// mutual TLS. CertFile and KeyFile must both be set, or both empty.
+	CertFile string
+
+	// KeyFile is the path to CertFile's private key.
+	KeyFile string
+
+	// Insecure disables verification of the collector's certificate.
+	// It is mutually exclusive with CAFile.
+	Insecure bool
+
+	// ServerName overrides the name used to verify the collector's
+	// certificate, when it differs from the dialed host.
+	ServerName string
+}
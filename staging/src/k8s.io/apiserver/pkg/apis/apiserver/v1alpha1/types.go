@@ -0,0 +1,180 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// OpenTelemetryClientConfiguration provides versioned configuration for opentelemetry clients.
+type OpenTelemetryClientConfiguration struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// URL is the endpoint to send spans to, in host:port form, with no
+	// scheme. Service and URL are mutually exclusive; if neither is set,
+	// a default URL is used.
+	// +optional
+	URL *string `json:"url,omitempty"`
+
+	// Service locates the collector via a Kubernetes Service rather
+	// than a fixed URL. Service and URL are mutually exclusive.
+	// +optional
+	Service *ServiceReference `json:"service,omitempty"`
+
+	// Exporter configures how spans are shipped out of the component.
+	// If unset, InitTraces picks its own default exporter.
+	// +optional
+	Exporter *ExporterConfig `json:"exporter,omitempty"`
+
+	// Sampling configures which traces this component starts on its
+	// own, rather than only continuing ones whose parent was already
+	// sampled.
+	// +optional
+	Sampling *SamplingPolicy `json:"sampling,omitempty"`
+
+	// TLS configures the transport security used to reach the
+	// collector. It only applies to the otlp exporter's gRPC
+	// connection; it is ignored by exporters that don't dial out over
+	// gRPC.
+	// +optional
+	TLS *TLSConfig `json:"tls,omitempty"`
+}
+
+// ServiceReference holds a reference to a Kubernetes Service that can be used to locate
+// the open telemetry collector
+type ServiceReference struct {
+	// Namespace is the namespace of the service
+	Namespace string `json:"namespace,omitempty"`
+
+	// Name is the name of the service
+	Name string `json:"name,omitempty"`
+
+	// Port is the port on the service that's hosting the open telemetry collector. Defaults to 55680.
+	// +optional
+	Port *int32 `json:"port,omitempty"`
+}
+
+// ExporterConfig selects and configures the exporter that ships spans out
+// of the component.
+type ExporterConfig struct {
+	// Type names the exporter to use, e.g. "otlp", "jaeger", "zipkin" or
+	// "stdout". Defaults to whatever InitTraces' caller chooses.
+	// +optional
+	Type string `json:"type,omitempty"`
+
+	// Endpoint is the collector address the exporter dials or posts
+	// to. Its syntax (host:port, or a full URL) depends on Type.
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// Headers are additional headers sent with every exported batch,
+	// e.g. for collector authentication. Only the otlp exporter sends
+	// them; the other exporters ignore them.
+	// +optional
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// Insecure disables transport security for the exporter's
+	// connection. It is mutually exclusive with TLS.
+	// +optional
+	Insecure bool `json:"insecure,omitempty"`
+
+	// Protocol selects the wire protocol the otlp exporter dials with,
+	// e.g. "grpc" or "http/protobuf". It is only meaningful when Type
+	// is "otlp".
+	// +optional
+	Protocol string `json:"protocol,omitempty"`
+}
+
+// SamplingPolicy configures which traces a component starts on its own.
+type SamplingPolicy struct {
+	// Type selects the fallback sampling strategy applied when none of
+	// Rules match, e.g. "Always", "Never", "ParentBased" or
+	// "TraceIDRatio".
+	// +optional
+	Type string `json:"type,omitempty"`
+
+	// TraceRatio is the fraction of traces to start, between 0 and 1,
+	// used when no rule matches and Type is "TraceIDRatio" (the default)
+	// or "ParentBased". Rules use their own Ratio instead.
+	// +optional
+	TraceRatio float64 `json:"traceRatio,omitempty"`
+
+	// Rules are evaluated in order before falling back to Type; the
+	// first matching rule decides whether and how often to sample.
+	// +optional
+	Rules []SamplingRule `json:"rules,omitempty"`
+}
+
+// SamplingRule matches requests by verb, resource, namespace and/or user
+// agent, and decides how the matching requests are sampled.
+type SamplingRule struct {
+	// Verb, if non-empty, restricts this rule to requests with this verb.
+	// +optional
+	Verb string `json:"verb,omitempty"`
+
+	// Resource, if non-empty, restricts this rule to requests against this resource.
+	// +optional
+	Resource string `json:"resource,omitempty"`
+
+	// Namespace, if non-empty, restricts this rule to requests in this namespace.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// UserAgent, if non-empty, restricts this rule to requests whose
+	// User-Agent contains this string.
+	// +optional
+	UserAgent string `json:"userAgent,omitempty"`
+
+	// Ratio is the fraction of matching requests to sample, between 0 and 1.
+	// +optional
+	Ratio float64 `json:"ratio,omitempty"`
+
+	// Drop, if true, means matching requests are never sampled,
+	// regardless of Ratio.
+	// +optional
+	Drop bool `json:"drop,omitempty"`
+}
+
+// TLSConfig configures the transport security used to reach the collector.
+type TLSConfig struct {
+	// CAFile is the path to a PEM-encoded CA bundle used to verify the
+	// collector's certificate. It is watched and reloaded, so its
+	// contents can be rotated without restarting the component.
+	// +optional
+	CAFile string `json:"caFile,omitempty"`
+
+	// CertFile is the path to a PEM-encoded client certificate, for
+	// mutual TLS. CertFile and KeyFile must both be set, or both empty.
+	// +optional
+	CertFile string `json:"certFile,omitempty"`
+
+	// KeyFile is the path to CertFile's private key.
+	// +optional
+	KeyFile string `json:"keyFile,omitempty"`
+
+	// Insecure disables verification of the collector's certificate.
+	// It is mutually exclusive with CAFile.
+	// +optional
+	Insecure bool `json:"insecure,omitempty"`
+
+	// ServerName overrides the name used to verify the collector's
+	// certificate, when it differs from the dialed host.
+	// +optional
+	ServerName string `json:"serverName,omitempty"`
+}
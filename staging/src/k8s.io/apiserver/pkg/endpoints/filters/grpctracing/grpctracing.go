@@ -0,0 +1,291 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package grpctracing is the gRPC analogue of
+// k8s.io/apiserver/pkg/endpoints/filters.WithTracing: it lets aggregated
+// API servers, webhook admission clients, and the storage layer's etcd
+// client participate in the same OpenTelemetry trace as the incoming
+// HTTP request.
+package grpctracing
+
+import (
+	"context"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "k8s.io/apiserver/pkg/endpoints/filters/grpctracing"
+
+// metadataCarrier adapts gRPC metadata.MD to propagation.TextMapCarrier so
+// a TraceContext+Baggage propagator can read and write it like an HTTP
+// header map.
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	values := metadata.MD(c).Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// UnaryServerInterceptor returns a grpc.UnaryServerInterceptor that
+// extracts a remote span context from the incoming metadata (propagated
+// by propagators), starts a child span using tp, and records the RPC
+// method, peer address, and resulting status code.
+func UnaryServerInterceptor(tp trace.TracerProvider, propagators propagation.TextMapPropagator) grpc.UnaryServerInterceptor {
+	tracer := tp.Tracer(tracerName)
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx = extract(ctx, propagators)
+		ctx, span := tracer.Start(ctx, info.FullMethod, trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(rpcAttributes(ctx, info.FullMethod)...))
+		defer span.End()
+
+		resp, err := handler(ctx, req)
+		recordStatus(span, err)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor is the streaming analogue of
+// UnaryServerInterceptor.
+func StreamServerInterceptor(tp trace.TracerProvider, propagators propagation.TextMapPropagator) grpc.StreamServerInterceptor {
+	tracer := tp.Tracer(tracerName)
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := extract(ss.Context(), propagators)
+		ctx, span := tracer.Start(ctx, info.FullMethod, trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(rpcAttributes(ctx, info.FullMethod)...))
+		defer span.End()
+
+		err := handler(srv, &tracedServerStream{ServerStream: ss, ctx: ctx, span: span})
+		recordStatus(span, err)
+		return err
+	}
+}
+
+// UnaryClientInterceptor returns a grpc.UnaryClientInterceptor that starts
+// a client span for the outgoing RPC and injects its context into the
+// outgoing metadata so the callee can continue the trace.
+func UnaryClientInterceptor(tp trace.TracerProvider, propagators propagation.TextMapPropagator) grpc.UnaryClientInterceptor {
+	tracer := tp.Tracer(tracerName)
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx, span := tracer.Start(ctx, method, trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(rpcAttributes(ctx, method)...))
+		defer span.End()
+
+		ctx = inject(ctx, propagators)
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		recordStatus(span, err)
+		return err
+	}
+}
+
+// StreamClientInterceptor is the streaming analogue of
+// UnaryClientInterceptor.
+func StreamClientInterceptor(tp trace.TracerProvider, propagators propagation.TextMapPropagator) grpc.StreamClientInterceptor {
+	tracer := tp.Tracer(tracerName)
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		ctx, span := tracer.Start(ctx, method, trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(rpcAttributes(ctx, method)...))
+
+		ctx = inject(ctx, propagators)
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			recordStatus(span, err)
+			span.End()
+			return nil, err
+		}
+		return newTracedClientStream(stream, span), nil
+	}
+}
+
+// DialOption wraps UnaryClientInterceptor in a grpc.DialOption for
+// callers that just want to opt an outbound connection into tracing, e.g.
+// the aggregator's proxy client or a webhook admission client. Only unary
+// RPCs are traced by it; streaming RPCs on the connection are not.
+func DialOption(tp trace.TracerProvider, propagators propagation.TextMapPropagator) grpc.DialOption {
+	return grpc.WithChainUnaryInterceptor(UnaryClientInterceptor(tp, propagators))
+}
+
+// ServerOption is the server-side analogue of DialOption: it installs
+// UnaryServerInterceptor only, so streaming RPCs are not traced by it.
+func ServerOption(tp trace.TracerProvider, propagators propagation.TextMapPropagator) grpc.ServerOption {
+	return grpc.ChainUnaryInterceptor(UnaryServerInterceptor(tp, propagators))
+}
+
+func extract(ctx context.Context, propagators propagation.TextMapPropagator) context.Context {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		md = metadata.MD{}
+	}
+	return propagators.Extract(ctx, metadataCarrier(md))
+}
+
+func inject(ctx context.Context, propagators propagation.TextMapPropagator) context.Context {
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if !ok {
+		md = metadata.MD{}
+	} else {
+		md = md.Copy()
+	}
+	propagators.Inject(ctx, metadataCarrier(md))
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+func rpcAttributes(ctx context.Context, fullMethod string) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		attribute.String("rpc.system", "grpc"),
+		attribute.String("rpc.method", fullMethod),
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		attrs = append(attrs, attribute.String("net.peer.addr", p.Addr.String()))
+	}
+	return attrs
+}
+
+func recordStatus(span trace.Span, err error) {
+	code := codes.OK
+	if err != nil {
+		code = status.Code(err)
+	}
+	span.SetAttributes(attribute.Int64("rpc.grpc.status_code", int64(code)))
+	if err != nil {
+		span.RecordError(err)
+	}
+}
+
+// recordMessage attaches a message-size event to span if m exposes its
+// wire size (true of every gogo/protobuf message this repo sends over
+// gRPC); it's a no-op event for any m that doesn't.
+func recordMessage(span trace.Span, eventName string, m interface{}) {
+	sizer, ok := m.(interface{ Size() int })
+	if !ok {
+		span.AddEvent(eventName)
+		return
+	}
+	span.AddEvent(eventName, trace.WithAttributes(attribute.Int("rpc.message.uncompressed_size", sizer.Size())))
+}
+
+const (
+	messageSentEvent     = "message.sent"
+	messageReceivedEvent = "message.received"
+)
+
+// tracedServerStream wraps a grpc.ServerStream to carry the traced
+// context and attach message-size attributes to the span as messages
+// flow through it.
+type tracedServerStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	span trace.Span
+}
+
+func (s *tracedServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *tracedServerStream) SendMsg(m interface{}) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		recordMessage(s.span, messageSentEvent, m)
+	}
+	return err
+}
+
+func (s *tracedServerStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		recordMessage(s.span, messageReceivedEvent, m)
+	}
+	return err
+}
+
+// tracedClientStream wraps a grpc.ClientStream so the client span is
+// ended once the stream completes, rather than immediately after the
+// streamer returns. A stream can finish in more ways than "RecvMsg
+// returned io.EOF or an error": a caller may never call RecvMsg at all,
+// so a failed CloseSend and the stream context finishing are also
+// treated as completion (a successful CloseSend is not, since responses
+// may still follow it); endOnce keeps whichever of these fires first
+// from double-ending the span.
+type tracedClientStream struct {
+	grpc.ClientStream
+	span    trace.Span
+	endOnce sync.Once
+}
+
+func newTracedClientStream(stream grpc.ClientStream, span trace.Span) *tracedClientStream {
+	s := &tracedClientStream{ClientStream: stream, span: span}
+	go func() {
+		<-stream.Context().Done()
+		s.end(nil)
+	}()
+	return s
+}
+
+func (s *tracedClientStream) end(err error) {
+	s.endOnce.Do(func() {
+		if err != nil {
+			recordStatus(s.span, err)
+		}
+		s.span.End()
+	})
+}
+
+func (s *tracedClientStream) SendMsg(m interface{}) error {
+	err := s.ClientStream.SendMsg(m)
+	if err == nil {
+		recordMessage(s.span, messageSentEvent, m)
+	}
+	return err
+}
+
+func (s *tracedClientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err != nil {
+		s.end(err)
+		return err
+	}
+	recordMessage(s.span, messageReceivedEvent, m)
+	return nil
+}
+
+func (s *tracedClientStream) CloseSend() error {
+	err := s.ClientStream.CloseSend()
+	if err != nil {
+		s.end(err)
+	}
+	return err
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpctracing
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"k8s.io/apiserver/pkg/endpoints/filters"
+)
+
+// webhookServiceDesc is a minimal hand-registered gRPC service standing in
+// for a real admission webhook backend: its only job is to record the
+// trace ID it observes so the test can assert it matches the trace ID
+// the incoming HTTP request started.
+var webhookServiceDesc = grpc.ServiceDesc{
+	ServiceName: "webhooktest.Webhook",
+	HandlerType: (*interface{})(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Review",
+			Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+				review := func(ctx context.Context, _ interface{}) (interface{}, error) {
+					srv.(*webhookServer).review(ctx)
+					return &struct{}{}, nil
+				}
+				// The server's interceptor only runs if the method handler
+				// calls it, as generated handlers do.
+				if interceptor == nil {
+					return review(ctx, nil)
+				}
+				return interceptor(ctx, nil, &grpc.UnaryServerInfo{Server: srv, FullMethod: "/webhooktest.Webhook/Review"}, review)
+			},
+		},
+	},
+}
+
+// emptyCodec lets the test exchange empty messages without generated
+// protobuf types: the default proto codec rejects the nil request and
+// *struct{} reply used here, since neither is a proto.Message.
+type emptyCodec struct{}
+
+func (emptyCodec) Marshal(interface{}) ([]byte, error) { return nil, nil }
+func (emptyCodec) Unmarshal([]byte, interface{}) error { return nil }
+func (emptyCodec) Name() string                        { return "webhooktest-empty" }
+func (emptyCodec) String() string                      { return "webhooktest-empty" }
+
+type webhookServer struct {
+	observedTraceID chan string
+}
+
+func (s *webhookServer) review(ctx context.Context) {
+	s.observedTraceID <- trace.SpanFromContext(ctx).SpanContext().TraceID().String()
+}
+
+// TestTraceSurvivesHTTPThenGRPC simulates the path a trace takes when an
+// incoming HTTP request (instrumented by filters.WithTracing) triggers an
+// outbound gRPC call to a webhook-like backend: the trace ID observed by
+// the gRPC server must match the trace ID of the span that the HTTP
+// handler started.
+func TestTraceSurvivesHTTPThenGRPC(t *testing.T) {
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSampler(sdktrace.AlwaysSample()))
+	propagators := otel.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+
+	// filters.WithTracing looks up its tracer via otel.Tracer(...), which
+	// resolves against the global TracerProvider, not tp directly; without
+	// registering tp globally the HTTP-side span comes from the no-op
+	// default provider and can never share a trace ID with the gRPC
+	// client span started explicitly from tp below.
+	prevTP := otel.GetTracerProvider()
+	otel.SetTracerProvider(tp)
+	defer otel.SetTracerProvider(prevTP)
+
+	lis := bufconn.Listen(1024 * 1024)
+	defer lis.Close()
+
+	backend := &webhookServer{observedTraceID: make(chan string, 1)}
+	grpcServer := grpc.NewServer(grpc.CustomCodec(emptyCodec{}), grpc.UnaryInterceptor(UnaryServerInterceptor(tp, propagators)))
+	grpcServer.RegisterService(&webhookServiceDesc, backend)
+	defer grpcServer.Stop()
+	go grpcServer.Serve(lis)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(_ context.Context, _ string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(UnaryClientInterceptor(tp, propagators)),
+		grpc.WithDefaultCallOptions(grpc.ForceCodec(emptyCodec{})),
+	)
+	if err != nil {
+		t.Fatalf("failed to dial bufconn: %v", err)
+	}
+	defer conn.Close()
+
+	var observedTraceID string
+	handler := filters.WithTracing(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		observedTraceID = trace.SpanFromContext(r.Context()).SpanContext().TraceID().String()
+
+		if err := conn.Invoke(r.Context(), "/webhooktest.Webhook/Review", nil, &struct{}{}); err != nil {
+			t.Errorf("webhook invoke failed: %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if observedTraceID == "" {
+		t.Fatal("expected the HTTP handler to observe a trace ID")
+	}
+
+	select {
+	case gotTraceID := <-backend.observedTraceID:
+		if gotTraceID != observedTraceID {
+			t.Errorf("expected webhook call to carry trace ID %q, got %q", observedTraceID, gotTraceID)
+		}
+	default:
+		t.Fatal("webhook backend never received the Review call")
+	}
+}
@@ -14,14 +14,167 @@ limitations under the License.
 package filters
 
 import (
+	"context"
 	"net/http"
 
-	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/propagators"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/semconv"
+	"go.opentelemetry.io/otel/trace"
+
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+)
+
+// Attribute keys attached to the request span before the TracerProvider's
+// Sampler runs, so a traces.SamplingPolicy can key its decision off the
+// verb/resource/namespace of the request and not just its URL.
+const (
+	VerbAttributeKey      = attribute.Key("k8s.verb")
+	ResourceAttributeKey  = attribute.Key("k8s.resource")
+	NamespaceAttributeKey = attribute.Key("k8s.namespace")
+	UserAgentAttributeKey = attribute.Key("k8s.user_agent")
 )
 
-// WithTracing adds tracing to requests if the incoming request is sampled
+var tracePropagators = otel.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+
+// WithTracing adds tracing to requests: it continues the incoming
+// request's trace if it carries one, and otherwise the TracerProvider's
+// sampler decides whether a new trace is started.
+//
+// It starts the span itself, rather than delegating to otelhttp.NewHandler,
+// because the verb/resource/namespace a SamplingPolicy rule matches on
+// only become available once WithRequestInfo (earlier in the filter
+// chain) has run, and a head-based Sampler only ever sees the attributes
+// passed to Tracer.Start -- attributes added to the span afterwards can't
+// change a sampling decision that has already been made.
 func WithTracing(handler http.Handler) http.Handler {
-	return otelhttp.NewHandler(handler, "KubernetesAPI", otelhttp.WithPropagators(otel.NewCompositeTextMapPropagator(propagators.TraceContext{}, propagators.Baggage{})))
+	tracer := otel.Tracer("k8s.io/apiserver")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := tracePropagators.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+		attrs := append(requestAttributes(r), requestInfoAttributes(ctx)...)
+		ctx, span := tracer.Start(ctx, "KubernetesAPI", trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(attrs...))
+		defer span.End()
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		handler.ServeHTTP(wrapStatusRecorder(rec), r.WithContext(ctx))
+
+		span.SetAttributes(semconv.HTTPStatusCodeKey.Int(rec.status))
+	})
+}
+
+func requestAttributes(r *http.Request) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		semconv.HTTPMethodKey.String(r.Method),
+		semconv.HTTPTargetKey.String(r.URL.RequestURI()),
+		UserAgentAttributeKey.String(r.UserAgent()),
+	}
+}
+
+// requestInfoAttributes extracts the k8s.verb/resource/namespace
+// attributes from the RequestInfo that WithRequestInfo attaches to ctx.
+// It returns nil if no RequestInfo is present, e.g. for non-API paths.
+func requestInfoAttributes(ctx context.Context) []attribute.KeyValue {
+	info, ok := genericapirequest.RequestInfoFrom(ctx)
+	if !ok {
+		return nil
+	}
+
+	attrs := []attribute.KeyValue{
+		VerbAttributeKey.String(info.Verb),
+		ResourceAttributeKey.String(info.Resource),
+	}
+	if info.Namespace != "" {
+		attrs = append(attrs, NamespaceAttributeKey.String(info.Namespace))
+	}
+	return attrs
+}
+
+// statusRecorder captures the status code written to an
+// http.ResponseWriter so it can be recorded on the span once the handler
+// returns.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Watch responses are streamed through http.Flusher, and
+// exec/attach/portforward upgrade the connection through http.Hijacker
+// (paired with http.CloseNotifier to detect the client going away); all
+// three are optional interfaces a plain http.ResponseWriter need not
+// implement. wrapStatusRecorder returns a value implementing exactly the
+// combination *rec.ResponseWriter* itself implements, so a downstream
+// handler's type assertion for one of them still reflects reality
+// instead of either losing the capability or claiming one rec doesn't
+// actually have.
+func wrapStatusRecorder(rec *statusRecorder) http.ResponseWriter {
+	flusher, isFlusher := rec.ResponseWriter.(http.Flusher)
+	hijacker, isHijacker := rec.ResponseWriter.(http.Hijacker)
+	closeNotifier, isCloseNotifier := rec.ResponseWriter.(http.CloseNotifier)
+
+	switch {
+	case isFlusher && isHijacker && isCloseNotifier:
+		return &flusherHijackerCloseNotifierRecorder{rec, flusher, hijacker, closeNotifier}
+	case isFlusher && isHijacker:
+		return &flusherHijackerRecorder{rec, flusher, hijacker}
+	case isFlusher && isCloseNotifier:
+		return &flusherCloseNotifierRecorder{rec, flusher, closeNotifier}
+	case isHijacker && isCloseNotifier:
+		return &hijackerCloseNotifierRecorder{rec, hijacker, closeNotifier}
+	case isFlusher:
+		return &flusherRecorder{rec, flusher}
+	case isHijacker:
+		return &hijackerRecorder{rec, hijacker}
+	case isCloseNotifier:
+		return &closeNotifierRecorder{rec, closeNotifier}
+	default:
+		return rec
+	}
+}
+
+type flusherRecorder struct {
+	*statusRecorder
+	http.Flusher
+}
+
+type hijackerRecorder struct {
+	*statusRecorder
+	http.Hijacker
+}
+
+type closeNotifierRecorder struct {
+	*statusRecorder
+	http.CloseNotifier
+}
+
+type flusherHijackerRecorder struct {
+	*statusRecorder
+	http.Flusher
+	http.Hijacker
+}
+
+type flusherCloseNotifierRecorder struct {
+	*statusRecorder
+	http.Flusher
+	http.CloseNotifier
+}
+
+type hijackerCloseNotifierRecorder struct {
+	*statusRecorder
+	http.Hijacker
+	http.CloseNotifier
+}
+
+type flusherHijackerCloseNotifierRecorder struct {
+	*statusRecorder
+	http.Flusher
+	http.Hijacker
+	http.CloseNotifier
 }
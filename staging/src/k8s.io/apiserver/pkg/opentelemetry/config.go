@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/url"
+	"os"
 
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/validation/field"
@@ -100,9 +101,105 @@ func ValidateOpenTelemetryConfiguration(config *apiserver.OpenTelemetryClientCon
 	if config.URL != nil {
 		allErrs = append(allErrs, validateURL(*config.URL, field.NewPath("url"))...)
 	}
+	if config.Exporter != nil {
+		allErrs = append(allErrs, validateExporter(config.Exporter, field.NewPath("exporter"))...)
+	}
+	if config.Sampling != nil {
+		allErrs = append(allErrs, validateSamplingPolicy(config.Sampling, field.NewPath("sampling"))...)
+	}
+	if config.TLS != nil {
+		allErrs = append(allErrs, validateTLSConfig(config.TLS, field.NewPath("tls"))...)
+	}
+	if config.Exporter != nil && config.Exporter.Insecure && config.TLS != nil {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("exporter", "insecure"), true, "exporter.insecure and tls cannot both be set"))
+	}
 	return allErrs
 }
 
+func validateTLSConfig(tlsConfig *apiserver.TLSConfig, fldPath *field.Path) field.ErrorList {
+	allErrors := field.ErrorList{}
+
+	if tlsConfig.CAFile != "" {
+		allErrors = append(allErrors, validateFileExists(tlsConfig.CAFile, fldPath.Child("caFile"))...)
+	}
+	if tlsConfig.Insecure && tlsConfig.CAFile != "" {
+		allErrors = append(allErrors, field.Invalid(fldPath.Child("insecure"), true, "insecure and caFile cannot both be set"))
+	}
+	if (tlsConfig.CertFile == "") != (tlsConfig.KeyFile == "") {
+		allErrors = append(allErrors, field.Invalid(fldPath, tlsConfig, "certFile and keyFile must both be set, or both be empty"))
+	}
+	if tlsConfig.CertFile != "" {
+		allErrors = append(allErrors, validateFileExists(tlsConfig.CertFile, fldPath.Child("certFile"))...)
+	}
+	if tlsConfig.KeyFile != "" {
+		allErrors = append(allErrors, validateFileExists(tlsConfig.KeyFile, fldPath.Child("keyFile"))...)
+	}
+	return allErrors
+}
+
+func validateFileExists(path string, fldPath *field.Path) field.ErrorList {
+	if _, err := os.Stat(path); err != nil {
+		return field.ErrorList{field.Invalid(fldPath, path, fmt.Sprintf("unable to read file: %v", err))}
+	}
+	return nil
+}
+
+// knownSamplerTypes mirrors the sampler fallback strategies
+// k8s.io/component-base/traces.NewPolicySampler supports.
+var knownSamplerTypes = map[string]bool{
+	"Always":       true,
+	"Never":        true,
+	"ParentBased":  true,
+	"TraceIDRatio": true,
+}
+
+func validateSamplingPolicy(policy *apiserver.SamplingPolicy, fldPath *field.Path) field.ErrorList {
+	allErrors := field.ErrorList{}
+
+	if policy.Type != "" && !knownSamplerTypes[policy.Type] {
+		allErrors = append(allErrors, field.NotSupported(fldPath.Child("type"), policy.Type, []string{"Always", "Never", "ParentBased", "TraceIDRatio"}))
+	}
+	if policy.TraceRatio < 0 || policy.TraceRatio > 1 {
+		allErrors = append(allErrors, field.Invalid(fldPath.Child("traceRatio"), policy.TraceRatio, "must be between 0 and 1"))
+	}
+	for i, rule := range policy.Rules {
+		if rule.Drop {
+			continue
+		}
+		if rule.Ratio < 0 || rule.Ratio > 1 {
+			allErrors = append(allErrors, field.Invalid(fldPath.Child("rules").Index(i).Child("ratio"), rule.Ratio, "must be between 0 and 1"))
+		}
+	}
+	return allErrors
+}
+
+// knownExporterTypes mirrors the exporters k8s.io/component-base/traces
+// registers by default. Out-of-tree exporters registered at runtime via
+// traces.RegisterExporter aren't known here, so this only rejects typos
+// in the common case; components using a custom exporter may see this
+// validation pass through a name InitTraces will still reject.
+var knownExporterTypes = map[string]bool{
+	"otlp":   true,
+	"jaeger": true,
+	"zipkin": true,
+	"stdout": true,
+}
+
+func validateExporter(exporter *apiserver.ExporterConfig, fldPath *field.Path) field.ErrorList {
+	allErrors := field.ErrorList{}
+
+	if exporter.Type != "" && !knownExporterTypes[exporter.Type] {
+		allErrors = append(allErrors, field.NotSupported(fldPath.Child("type"), exporter.Type, []string{"otlp", "jaeger", "zipkin", "stdout"}))
+	}
+	if exporter.Endpoint != "" {
+		allErrors = append(allErrors, validateURL(exporter.Endpoint, fldPath.Child("endpoint"))...)
+	}
+	if exporter.Protocol != "" && exporter.Protocol != "grpc" && exporter.Protocol != "http/protobuf" {
+		allErrors = append(allErrors, field.NotSupported(fldPath.Child("protocol"), exporter.Protocol, []string{"grpc", "http/protobuf"}))
+	}
+	return allErrors
+}
+
 func validateService(service *apiserver.ServiceReference, fldPath *field.Path) field.ErrorList {
 	allErrors := field.ErrorList{}
 
@@ -22,10 +22,11 @@ import (
 	"net"
 
 	"github.com/spf13/pflag"
-	"go.opentelemetry.io/otel/exporters/otlp"
+	"go.opentelemetry.io/otel"
 	"google.golang.org/grpc"
 	"k8s.io/utils/path"
 
+	"k8s.io/apiserver/pkg/apis/apiserver"
 	"k8s.io/apiserver/pkg/opentelemetry"
 	"k8s.io/apiserver/pkg/server/egressselector"
 	"k8s.io/component-base/traces"
@@ -73,9 +74,26 @@ func (o *OpenTelemetryOptions) Apply(es *egressselector.EgressSelector) error {
 		return nil
 	}
 
-	opts := []otlp.ExporterOption{}
+	cfg := traces.ExporterConfig{}
+	if npConfig.Exporter != nil {
+		cfg.Type = traces.ExporterType(npConfig.Exporter.Type)
+		cfg.Endpoint = npConfig.Exporter.Endpoint
+		cfg.Headers = npConfig.Exporter.Headers
+		cfg.Insecure = npConfig.Exporter.Insecure
+		cfg.OTLPProtocol = traces.OTLPProtocol(npConfig.Exporter.Protocol)
+	}
+	if npConfig.TLS != nil {
+		cfg.TLS = &traces.TLSConfig{
+			CAFile:     npConfig.TLS.CAFile,
+			CertFile:   npConfig.TLS.CertFile,
+			KeyFile:    npConfig.TLS.KeyFile,
+			Insecure:   npConfig.TLS.Insecure,
+			ServerName: npConfig.TLS.ServerName,
+		}
+	}
+
 	if npConfig.URL != nil {
-		opts = append(opts, otlp.WithAddress(*npConfig.URL))
+		cfg.Endpoint = *npConfig.URL
 
 		if es != nil {
 			// Only use the egressselector dialer if egressselector is enabled.
@@ -88,7 +106,7 @@ func (o *OpenTelemetryOptions) Apply(es *egressselector.EgressSelector) error {
 			otelDialer := func(ctx context.Context, addr string) (net.Conn, error) {
 				return egressDialer(ctx, "tcp", addr)
 			}
-			opts = append(opts, otlp.WithGRPCDialOption(grpc.WithContextDialer(otelDialer)))
+			cfg.GRPCDialOptions = append(cfg.GRPCDialOptions, grpc.WithContextDialer(otelDialer))
 		}
 	}
 	if npConfig.Service != nil {
@@ -97,8 +115,7 @@ func (o *OpenTelemetryOptions) Apply(es *egressselector.EgressSelector) error {
 		if npConfig.Service.Port != nil {
 			port = *npConfig.Service.Port
 		}
-		addr := fmt.Sprintf("%s.%s:%d", npConfig.Service.Name, npConfig.Service.Namespace, port)
-		opts = append(opts, otlp.WithAddress(addr))
+		cfg.Endpoint = fmt.Sprintf("%s.%s:%d", npConfig.Service.Name, npConfig.Service.Namespace, port)
 
 		if es != nil {
 			// Only use the egressselector dialer if egressselector is enabled.
@@ -116,12 +133,61 @@ func (o *OpenTelemetryOptions) Apply(es *egressselector.EgressSelector) error {
 			otelDialer := func(ctx context.Context, addr string) (net.Conn, error) {
 				return egressDialer(ctx, "tcp", addr)
 			}
-			opts = append(opts, otlp.WithGRPCDialOption(grpc.WithContextDialer(otelDialer)))
+			cfg.GRPCDialOptions = append(cfg.GRPCDialOptions, grpc.WithContextDialer(otelDialer))
 		}
 	}
 
-	traces.InitTraces("kube-apiserver", opts...)
-	return nil
+	initOpts := []traces.Option{}
+	if npConfig.Sampling != nil {
+		initOpts = append(initOpts, traces.WithSamplingPolicy(convertSamplingPolicy(*npConfig.Sampling)))
+	}
+
+	// Instrument the exporter's own connection to the collector, so the
+	// OTLP dialer carries spans the same way any other traced gRPC
+	// client does.
+	cfg.GRPCDialOptions = append(cfg.GRPCDialOptions, o.ClientDialOption())
+
+	return traces.InitTraces("kube-apiserver", cfg, initOpts...)
+}
+
+// ClientDialOption returns a grpc.DialOption that instruments an outbound
+// gRPC connection with the TracerProvider Apply configured. Apply uses it
+// on the exporter's own connection to the collector; components dialing
+// CRI, aggregated API servers, or admission webhooks can also call it to
+// opt their own connections into the same tracing setup. Call it only
+// after Apply has run.
+func (o *OpenTelemetryOptions) ClientDialOption() grpc.DialOption {
+	return traces.ClientStatsHandler(otel.GetTracerProvider())
+}
+
+// ServerOption returns a grpc.ServerOption that starts a parent span for
+// each request a gRPC server receives, so incoming calls become traced
+// without needing the pkg/util/httptrace annotation dance. kube-apiserver
+// doesn't run a gRPC server itself, so nothing in this repo calls this
+// yet; it exists for out-of-tree gRPC servers (e.g. a custom admission
+// webhook) that want to join the same trace. Call it only after Apply has
+// run.
+func (o *OpenTelemetryOptions) ServerOption() grpc.ServerOption {
+	return traces.ServerStatsHandler(otel.GetTracerProvider())
+}
+
+func convertSamplingPolicy(policy apiserver.SamplingPolicy) traces.SamplingPolicy {
+	rules := make([]traces.SamplingRule, 0, len(policy.Rules))
+	for _, rule := range policy.Rules {
+		rules = append(rules, traces.SamplingRule{
+			Verb:      rule.Verb,
+			Resource:  rule.Resource,
+			Namespace: rule.Namespace,
+			UserAgent: rule.UserAgent,
+			Ratio:     rule.Ratio,
+			Drop:      rule.Drop,
+		})
+	}
+	return traces.SamplingPolicy{
+		Type:       traces.SamplerType(policy.Type),
+		TraceRatio: policy.TraceRatio,
+		Rules:      rules,
+	}
 }
 
 // Validate verifies flags passed to OpenTelemetryOptions.
@@ -0,0 +1,126 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package etcd3
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"go.etcd.io/etcd/integration"
+)
+
+// TestTracingKVOnlyTracesSampledRequests verifies the parent-based
+// behavior called for in the design: a Get on an unsampled context must
+// not produce a span, so unsampled requests pay no storage-layer tracing
+// overhead.
+func TestTracingKVOnlyTracesSampledRequests(t *testing.T) {
+	cluster := integration.NewClusterV3(t, &integration.ClusterConfig{Size: 1})
+	defer cluster.Terminate(t)
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	kv := NewTracingKV(cluster.RandClient().KV, tp)
+
+	if _, err := kv.Get(context.Background(), "/unsampled"); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got := len(recorder.Ended()); got != 0 {
+		t.Errorf("expected no spans for an unsampled context, got %d", got)
+	}
+
+	ctx, span := tp.Tracer("test").Start(context.Background(), "root")
+	if _, err := kv.Get(ctx, "/sampled"); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	span.End()
+
+	var names []string
+	for _, s := range recorder.Ended() {
+		names = append(names, s.Name())
+	}
+	found := false
+	for _, n := range names {
+		if n == "etcd3.Get" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected an etcd3.Get span among %v", names)
+	}
+}
+
+// fakeExemplarObserver records the arguments its ObserveWithExemplar
+// receives so a test can assert on them without a real histogram.
+type fakeExemplarObserver struct {
+	value    float64
+	exemplar prometheus.Labels
+	called   bool
+}
+
+func (f *fakeExemplarObserver) ObserveWithExemplar(value float64, exemplar prometheus.Labels) {
+	f.called = true
+	f.value = value
+	f.exemplar = exemplar
+}
+
+// TestObserveWithExemplarWithoutSpan verifies ObserveWithExemplar still
+// records the observation, with a nil (no-op) exemplar, when ctx carries
+// no recording span.
+func TestObserveWithExemplarWithoutSpan(t *testing.T) {
+	fake := &fakeExemplarObserver{}
+
+	ObserveWithExemplar(context.Background(), fake, 1.5)
+
+	if !fake.called {
+		t.Fatal("expected the histogram to be observed")
+	}
+	if fake.value != 1.5 {
+		t.Errorf("expected value 1.5, got %v", fake.value)
+	}
+	if fake.exemplar != nil {
+		t.Errorf("expected a nil exemplar without a recording span, got %v", fake.exemplar)
+	}
+}
+
+// TestObserveWithExemplarWithSpan verifies ObserveWithExemplar attaches
+// the active span's trace and span IDs as the exemplar when ctx does
+// carry a recording span.
+func TestObserveWithExemplarWithSpan(t *testing.T) {
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSampler(sdktrace.AlwaysSample()))
+	ctx, span := tp.Tracer("test").Start(context.Background(), "root")
+	defer span.End()
+
+	fake := &fakeExemplarObserver{}
+	ObserveWithExemplar(ctx, fake, 2.5)
+
+	if !fake.called {
+		t.Fatal("expected the histogram to be observed")
+	}
+	sc := trace.SpanFromContext(ctx).SpanContext()
+	if got, want := fake.exemplar["trace_id"], sc.TraceID().String(); got != want {
+		t.Errorf("expected trace_id %q, got %q", want, got)
+	}
+	if got, want := fake.exemplar["span_id"], sc.SpanID().String(); got != want {
+		t.Errorf("expected span_id %q, got %q", want, got)
+	}
+}
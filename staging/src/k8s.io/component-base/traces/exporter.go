@@ -0,0 +1,220 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package traces
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/exporters/trace/jaeger"
+	"go.opentelemetry.io/otel/exporters/trace/zipkin"
+	sdktrace "go.opentelemetry.io/otel/sdk/export/trace"
+	"google.golang.org/grpc"
+)
+
+// ExporterType names one of the exporters registered with this package.
+type ExporterType string
+
+const (
+	// ExporterOTLP sends spans to an OpenTelemetry collector. It is the
+	// default used when a component doesn't set ExporterConfig.Type.
+	ExporterOTLP ExporterType = "otlp"
+	// ExporterJaeger sends spans directly to a Jaeger collector or agent.
+	ExporterJaeger ExporterType = "jaeger"
+	// ExporterZipkin sends spans to a Zipkin collector.
+	ExporterZipkin ExporterType = "zipkin"
+	// ExporterStdout writes spans to stdout; useful for local debugging.
+	ExporterStdout ExporterType = "stdout"
+)
+
+// OTLPProtocol selects the wire protocol an ExporterOTLP exporter speaks
+// to its collector.
+type OTLPProtocol string
+
+const (
+	// OTLPProtocolGRPC exports over OTLP/gRPC. It is the default, and
+	// the collector's usual port for it is 55680/4317.
+	OTLPProtocolGRPC OTLPProtocol = "grpc"
+	// OTLPProtocolHTTP exports over OTLP/HTTP (protobuf-encoded, POSTed
+	// to /v1/traces). The collector's usual port for it is 4318.
+	OTLPProtocolHTTP OTLPProtocol = "http/protobuf"
+)
+
+const defaultOTLPHTTPPath = "/v1/traces"
+
+// defaultOTLPHTTPEndpoint is the collector address newOTLPHTTPExporter
+// falls back to when cfg.Endpoint and OTEL_EXPORTER_OTLP_ENDPOINT are
+// both unset. otlptracegrpc and otlptracehttp are separate clients with
+// separate defaults (55680/4317 for gRPC, 4318 for HTTP); spelling this
+// out keeps the HTTP path from silently inheriting the gRPC port the way
+// it would if both protocols still shared one underlying client.
+const defaultOTLPHTTPEndpoint = "localhost:4318"
+
+// ExporterConfig describes which exporter a component should use to ship
+// its spans and how to reach it. Components translate their own
+// TracingConfiguration (e.g. apiserver.OpenTelemetryClientConfiguration)
+// into an ExporterConfig before calling InitTraces.
+type ExporterConfig struct {
+	// Type selects the exporter implementation. Defaults to ExporterOTLP.
+	Type ExporterType
+	// Endpoint is the collector address to export to. If empty, the
+	// exporter falls back to its own OTEL_EXPORTER_* environment
+	// variable (e.g. OTEL_EXPORTER_OTLP_ENDPOINT), matching the behavior
+	// every other OpenTelemetry SDK uses for that exporter.
+	Endpoint string
+	// Headers are additional headers attached to every export request.
+	// Only honored by the otlp exporter (over either protocol); jaeger,
+	// zipkin and stdout ignore them.
+	Headers map[string]string
+	// Insecure disables TLS/credentials negotiation with the collector.
+	Insecure bool
+	// GRPCDialOptions are appended to the dial options of exporters that
+	// talk gRPC to their collector (currently just otlp over
+	// OTLPProtocolGRPC). Components use this to route the OTLP
+	// connection through an egress selector dialer, the same way they do
+	// for other outbound gRPC traffic.
+	GRPCDialOptions []grpc.DialOption
+	// OTLPProtocol selects which wire protocol ExporterOTLP speaks.
+	// Defaults to OTLPProtocolGRPC. Ignored by every other exporter
+	// type.
+	OTLPProtocol OTLPProtocol
+	// TLS configures the connection to the collector. If nil, Insecure
+	// determines whether a plaintext or default-verification TLS
+	// connection is used. Only honored by exporters that dial gRPC
+	// (currently otlp over OTLPProtocolGRPC).
+	TLS *TLSConfig
+}
+
+// exporterFactory constructs a span exporter from an ExporterConfig. It is
+// the extension point out-of-tree components use via RegisterExporter to
+// add exporters this package doesn't know about.
+type exporterFactory func(ExporterConfig) (sdktrace.SpanExporter, error)
+
+var (
+	exportersMu sync.RWMutex
+	exporters   = map[ExporterType]exporterFactory{
+		ExporterOTLP:   newOTLPExporter,
+		ExporterJaeger: newJaegerExporter,
+		ExporterZipkin: newZipkinExporter,
+		ExporterStdout: newStdoutExporter,
+	}
+)
+
+// RegisterExporter makes an exporter factory available under name, so that
+// components whose ExporterConfig.Type matches it can be constructed by
+// InitTraces. It is intended for out-of-tree exporters; calling it with a
+// name already registered overwrites the previous factory.
+func RegisterExporter(name ExporterType, factory exporterFactory) {
+	exportersMu.Lock()
+	defer exportersMu.Unlock()
+	exporters[name] = factory
+}
+
+func newExporter(cfg ExporterConfig) (sdktrace.SpanExporter, error) {
+	exporterType := cfg.Type
+	if exporterType == "" {
+		exporterType = ExporterOTLP
+	}
+
+	exportersMu.RLock()
+	factory, ok := exporters[exporterType]
+	exportersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown trace exporter %q", exporterType)
+	}
+	return factory(cfg)
+}
+
+func newOTLPExporter(cfg ExporterConfig) (sdktrace.SpanExporter, error) {
+	switch cfg.OTLPProtocol {
+	case "", OTLPProtocolGRPC:
+		return newOTLPGRPCExporter(cfg)
+	case OTLPProtocolHTTP:
+		return newOTLPHTTPExporter(cfg)
+	default:
+		return nil, fmt.Errorf("unknown otlp protocol %q", cfg.OTLPProtocol)
+	}
+}
+
+func newOTLPGRPCExporter(cfg ExporterConfig) (sdktrace.SpanExporter, error) {
+	opts := []otlptracegrpc.Option{}
+	switch {
+	case cfg.TLS != nil:
+		creds, err := newTLSCredentials(cfg.TLS)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, otlptracegrpc.WithTLSCredentials(creds))
+	case cfg.Insecure:
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	}
+	if cfg.Endpoint != "" {
+		opts = append(opts, otlptracegrpc.WithEndpoint(cfg.Endpoint))
+	}
+	if len(cfg.Headers) > 0 {
+		opts = append(opts, otlptracegrpc.WithHeaders(cfg.Headers))
+	}
+	for _, dialOpt := range cfg.GRPCDialOptions {
+		opts = append(opts, otlptracegrpc.WithDialOption(dialOpt))
+	}
+	return otlptrace.New(context.Background(), otlptracegrpc.NewClient(opts...))
+}
+
+func newOTLPHTTPExporter(cfg ExporterConfig) (sdktrace.SpanExporter, error) {
+	opts := []otlptracehttp.Option{otlptracehttp.WithURLPath(defaultOTLPHTTPPath)}
+	if cfg.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+	}
+	if endpoint == "" {
+		endpoint = defaultOTLPHTTPEndpoint
+	}
+	opts = append(opts, otlptracehttp.WithEndpoint(endpoint))
+	if len(cfg.Headers) > 0 {
+		opts = append(opts, otlptracehttp.WithHeaders(cfg.Headers))
+	}
+	return otlptrace.New(context.Background(), otlptracehttp.NewClient(opts...))
+}
+
+func newJaegerExporter(cfg ExporterConfig) (sdktrace.SpanExporter, error) {
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = os.Getenv("OTEL_EXPORTER_JAEGER_ENDPOINT")
+	}
+	return jaeger.NewRawExporter(jaeger.WithCollectorEndpoint(endpoint))
+}
+
+func newZipkinExporter(cfg ExporterConfig) (sdktrace.SpanExporter, error) {
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = os.Getenv("OTEL_EXPORTER_ZIPKIN_ENDPOINT")
+	}
+	return zipkin.NewRawExporter(endpoint)
+}
+
+func newStdoutExporter(cfg ExporterConfig) (sdktrace.SpanExporter, error) {
+	return stdouttrace.New(stdouttrace.WithPrettyPrint())
+}
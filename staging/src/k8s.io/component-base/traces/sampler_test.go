@@ -0,0 +1,138 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package traces
+
+import (
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestPolicySamplerRuleMatch(t *testing.T) {
+	policy := SamplingPolicy{
+		TraceRatio: 0,
+		Rules: []SamplingRule{
+			{Verb: "watch", Drop: true},
+			{Resource: "events", Ratio: 1},
+		},
+	}
+	sampler := NewPolicySampler(policy)
+
+	testcases := []struct {
+		name       string
+		attrs      []attribute.KeyValue
+		wantSample bool
+	}{
+		{
+			name:       "watch requests are dropped",
+			attrs:      []attribute.KeyValue{verbAttributeKey.String("watch"), resourceAttributeKey.String("pods")},
+			wantSample: false,
+		},
+		{
+			name:       "events always sampled",
+			attrs:      []attribute.KeyValue{verbAttributeKey.String("get"), resourceAttributeKey.String("events")},
+			wantSample: true,
+		},
+		{
+			name:       "unmatched falls back to traceRatio of 0",
+			attrs:      []attribute.KeyValue{verbAttributeKey.String("get"), resourceAttributeKey.String("pods")},
+			wantSample: false,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := sampler.ShouldSample(sdktrace.SamplingParameters{Attributes: tc.attrs})
+			sampled := result.Decision == sdktrace.RecordAndSample
+			if sampled != tc.wantSample {
+				t.Errorf("ShouldSample() sampled = %v, want %v", sampled, tc.wantSample)
+			}
+		})
+	}
+}
+
+func TestNewFallbackSampler(t *testing.T) {
+	testcases := []struct {
+		name       string
+		policyType SamplerType
+		wantSample bool
+	}{
+		{name: "always", policyType: SamplerAlways, wantSample: true},
+		{name: "never", policyType: SamplerNever, wantSample: false},
+		{name: "empty defaults to traceIDRatio of 0", policyType: "", wantSample: false},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			sampler := NewPolicySampler(SamplingPolicy{Type: tc.policyType, TraceRatio: 0})
+			result := sampler.ShouldSample(sdktrace.SamplingParameters{})
+			sampled := result.Decision == sdktrace.RecordAndSample
+			if sampled != tc.wantSample {
+				t.Errorf("ShouldSample() sampled = %v, want %v", sampled, tc.wantSample)
+			}
+		})
+	}
+}
+
+func TestValidateSamplingPolicy(t *testing.T) {
+	testcases := []struct {
+		name        string
+		policy      SamplingPolicy
+		expectError bool
+	}{
+		{
+			name:   "valid",
+			policy: SamplingPolicy{TraceRatio: 0.5, Rules: []SamplingRule{{Resource: "events", Ratio: 1}}},
+		},
+		{
+			name:   "valid with type",
+			policy: SamplingPolicy{Type: SamplerParentBased, TraceRatio: 0.5},
+		},
+		{
+			name:        "unknown type",
+			policy:      SamplingPolicy{Type: "bogus"},
+			expectError: true,
+		},
+		{
+			name:        "traceRatio out of range",
+			policy:      SamplingPolicy{TraceRatio: 1.5},
+			expectError: true,
+		},
+		{
+			name:        "rule ratio out of range",
+			policy:      SamplingPolicy{Rules: []SamplingRule{{Resource: "events", Ratio: -1}}},
+			expectError: true,
+		},
+		{
+			name:   "drop rule ignores ratio",
+			policy: SamplingPolicy{Rules: []SamplingRule{{Resource: "events", Drop: true, Ratio: -1}}},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			errs := ValidateSamplingPolicy(tc.policy)
+			if tc.expectError && len(errs) == 0 {
+				t.Errorf("expected an error, got none")
+			}
+			if !tc.expectError && len(errs) != 0 {
+				t.Errorf("expected no error, got %v", errs)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package traces
+
+import (
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+)
+
+// ClientStatsHandler returns a grpc.DialOption that instruments a gRPC
+// client connection with tp, continuing whatever trace the calling code
+// is already in across the gRPC boundary. Components use this to
+// instrument their own outbound connections (to CRI, to aggregated API
+// servers, to admission webhooks) once InitTraces has configured tp; it
+// is a thinner alternative to the interceptors in
+// k8s.io/apiserver/pkg/endpoints/filters/grpctracing for callers that
+// don't need fine-grained control over span attributes.
+func ClientStatsHandler(tp trace.TracerProvider) grpc.DialOption {
+	return grpc.WithStatsHandler(otelgrpc.NewClientHandler(otelgrpc.WithTracerProvider(tp)))
+}
+
+// ServerStatsHandler returns a grpc.ServerOption that starts a parent
+// span for each request a gRPC server receives, the server-side
+// counterpart to ClientStatsHandler.
+func ServerStatsHandler(tp trace.TracerProvider) grpc.ServerOption {
+	return grpc.StatsHandler(otelgrpc.NewServerHandler(otelgrpc.WithTracerProvider(tp)))
+}
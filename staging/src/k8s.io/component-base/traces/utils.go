@@ -17,8 +17,9 @@ limitations under the License.
 package traces
 
 import (
-	"go.opentelemetry.io/otel/api/global"
-	"go.opentelemetry.io/otel/exporters/otlp"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"go.opentelemetry.io/otel/semconv"
@@ -26,23 +27,58 @@ import (
 	"k8s.io/klog/v2"
 )
 
-// InitTraces initializes tracing in the component.
-// Components must use the OTLP exporter, but can pass additional exporter
-// options if needed
-func InitTraces(service string, opts ...otlp.ExporterOption) {
-	opts = append(opts, otlp.WithInsecure())
-	exporter, err := otlp.NewExporter(opts...)
+// Option customizes InitTraces beyond the exporter it ships to.
+type Option func(*initTracesOptions)
+
+type initTracesOptions struct {
+	sampler sdktrace.Sampler
+}
+
+// WithSamplingPolicy makes the apiserver start new traces (rather than
+// only continuing ones whose parent was already sampled) according to
+// policy. Without this option, InitTraces defaults to
+// ParentBased(NeverSample()), i.e. the component never samples on its
+// own.
+func WithSamplingPolicy(policy SamplingPolicy) Option {
+	return func(o *initTracesOptions) {
+		o.sampler = NewPolicySampler(policy)
+	}
+}
+
+// InitTraces initializes tracing in the component, constructing whichever
+// exporter cfg.Type selects (see RegisterExporter for the built-in
+// choices and how to add more) and registering it as the global tracer
+// provider.
+func InitTraces(service string, cfg ExporterConfig, opts ...Option) error {
+	exporter, err := newExporter(cfg)
 	if err != nil {
-		klog.Fatalf("Failed to create OTLP exporter: %v", err)
+		return fmt.Errorf("failed to create %s trace exporter: %v", cfg.Type, err)
 	}
 
-	// Use ParentBased(NeverSample()) to preserve the sampling decision of the
-	// parent, but not start additional spans.
+	o := initTracesOptions{
+		// Never sample on our own by default; only continue traces whose
+		// parent was already sampled.
+		sampler: sdktrace.NeverSample(),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	// Wrapping in ParentBased means a sampled parent always wins,
+	// regardless of what o.sampler (the root sampler) decides.
 	tp := sdktrace.NewTracerProvider(
-		sdktrace.WithConfig(sdktrace.Config{
-			DefaultSampler: sdktrace.ParentBased(sdktrace.NeverSample())},
-		),
+		sdktrace.WithSampler(sdktrace.ParentBased(o.sampler)),
 		sdktrace.WithBatcher(exporter),
-		sdktrace.WithResource(resource.New(semconv.ServiceNameKey.String(service))))
-	global.SetTracerProvider(tp)
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String(service))))
+	otel.SetTracerProvider(tp)
+	return nil
+}
+
+// MustInitTraces is like InitTraces, but calls klog.Fatalf instead of
+// returning an error. It exists for the common case of components that
+// cannot usefully continue without tracing configured as requested.
+func MustInitTraces(service string, cfg ExporterConfig, opts ...Option) {
+	if err := InitTraces(service, cfg, opts...); err != nil {
+		klog.Fatal(err.Error())
+	}
 }